@@ -0,0 +1,109 @@
+package myextract
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genai"
+)
+
+// CreateCache bundles the currently-uploaded files (and the system prompt, if
+// set) into a server-side CachedContent and returns its identifier. Passing
+// ttl <= 0 lets the API apply its own default TTL.
+// The returned cacheID can be handed to UseCache on this extractor, or stored
+// and reused across several Extractor instances / process runs.
+func (e *Extractor) CreateCache(ttl time.Duration) (cacheID string, err error) {
+	e.mu.Lock()
+	files := append([]*genai.File(nil), e.files...)
+	e.mu.Unlock()
+
+	if len(files) == 0 {
+		return "", fmt.Errorf("cannot create cache : no files uploaded")
+	}
+
+	// Build the cached parts from the currently uploaded files
+	parts := make([]*genai.Part, 0, len(files))
+	for _, f := range files {
+		parts = append(parts, genai.NewPartFromURI(f.URI, f.MIMEType))
+	}
+
+	ccc := &genai.CreateCachedContentConfig{
+		Contents: []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)},
+	}
+
+	// Carry over the system prompt so cached requests behave like live ones
+	if e.systInstr != nil {
+		ccc.SystemInstruction = e.systInstr
+	}
+
+	if ttl > 0 {
+		ccc.TTL = ttl
+	}
+
+	cc, err := e.client.Caches.Create(e.ctx, e.model, ccc)
+	if err != nil {
+		return "", err
+	}
+
+	e.log.Printf("Created cache (%s), ttl=%s\n", cc.Name, ttl)
+	return cc.Name, nil
+}
+
+// UseCache makes subsequent Extract calls reference cacheID instead of
+// re-sending the uploaded files' URIs. An empty cacheID clears the cache,
+// reverting Extract to its normal, uncached behaviour.
+func (e *Extractor) UseCache(cacheID string) *Extractor {
+	e.mu.Lock()
+	e.cacheID = cacheID
+	e.mu.Unlock()
+	return e
+}
+
+// UpdateCacheTTL refreshes the expiration of the currently active cache
+// (set via UseCache or returned by CreateCache). It is an error to call this
+// when no cache is active.
+func (e *Extractor) UpdateCacheTTL(ttl time.Duration) error {
+	e.mu.Lock()
+	cacheID := e.cacheID
+	e.mu.Unlock()
+
+	if cacheID == "" {
+		return fmt.Errorf("cannot update cache ttl : no active cache")
+	}
+
+	_, err := e.client.Caches.Update(e.ctx, cacheID, &genai.UpdateCachedContentConfig{
+		TTL: ttl,
+	})
+	return err
+}
+
+// DeleteCache removes cacheID from the API. If cacheID is the currently
+// active cache (see UseCache), the extractor stops referencing it.
+// Safe to call on an already-deleted or unknown cache id.
+func (e *Extractor) DeleteCache(cacheID string) error {
+	if cacheID == "" {
+		return nil
+	}
+
+	_, err := e.client.Caches.Delete(e.ctx, cacheID, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	e.mu.Lock()
+	if e.cacheID == cacheID {
+		e.cacheID = ""
+	}
+	e.mu.Unlock()
+	return nil
+}
+
+// isNotFound reports whether err is the API's 404 response, meaning the
+// cache id is already gone (expired or previously deleted).
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
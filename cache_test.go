@@ -0,0 +1,41 @@
+package myextract
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExtractorCache(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	err = e.Upload(filepath.Join("testFiles", "pdf.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cacheID, err := e.CreateCache(5 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.UseCache(cacheID)
+
+	r, err := e.Extract(nil, "Résume ce fichier en 3 lignes.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+
+	if err := e.UpdateCacheTTL(10 * time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.DeleteCache(cacheID); err != nil {
+		t.Fatal(err)
+	}
+}
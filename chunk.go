@@ -0,0 +1,307 @@
+package myextract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// Rough characters-per-token ratio used to estimate token counts locally,
+// without a round-trip to the API's tokenizer.
+const approxCharsPerToken = 4
+
+// ChunkOptions configures ExtractLarge's map-reduce behaviour.
+type ChunkOptions struct {
+	MaxInputTokens int // Token budget below which ExtractLarge behaves like Extract (single-shot). <= 0 defaults to 30000.
+	ChunkTokens    int // Target size of each chunk, in tokens. <= 0 defaults to 8000.
+	OverlapTokens  int // Tokens of overlap between consecutive chunks, to preserve context across a cut. <= 0 means no overlap.
+	Concurrency    int // Number of chunks processed in parallel during the map phase. <= 0 defaults to 4.
+}
+
+func (o ChunkOptions) withDefaults() ChunkOptions {
+	if o.MaxInputTokens <= 0 {
+		o.MaxInputTokens = 30000
+	}
+	if o.ChunkTokens <= 0 {
+		o.ChunkTokens = 8000
+	}
+	if o.OverlapTokens < 0 {
+		o.OverlapTokens = 0
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	return o
+}
+
+// ExtractLarge behaves like Extract, except that when the content currently
+// uploaded exceeds opts' token budget it switches to a map-reduce strategy:
+// inputs are split into overlapping chunks (map phase, run in parallel),
+// then a final call merges and deduplicates the per-chunk results (reduce
+// phase). Below the budget it simply delegates to Extract.
+// When schema is set, the merged result is checked for schema conformance
+// and the reduce call is retried once if it doesn't conform.
+func (e *Extractor) ExtractLarge(schema *genai.Schema, prompt string, opts ChunkOptions) (string, error) {
+	opts = opts.withDefaults()
+
+	if e.estimateUploadedTokens() <= opts.MaxInputTokens {
+		return e.Extract(schema, prompt)
+	}
+
+	chunks := e.buildChunks(opts)
+	if len(chunks) == 0 {
+		return e.Extract(schema, prompt)
+	}
+
+	partials, err := e.mapChunks(schema, prompt, chunks, opts.Concurrency)
+	if err != nil {
+		return "", err
+	}
+
+	return e.reduce(schema, prompt, partials)
+}
+
+// estimateUploadedTokens returns a rough token count for everything
+// currently uploaded, based on content length rather than the API's actual
+// tokenizer.
+func (e *Extractor) estimateUploadedTokens() int {
+	var total int
+	for _, data := range e.fileData {
+		total += len(data) / approxCharsPerToken
+	}
+	return total
+}
+
+// chunk is a single map-phase unit: a slice of one uploaded file's content,
+// tagged with the MIME type it should be re-uploaded under.
+type chunk struct {
+	data []byte
+	mime string
+}
+
+// buildChunks splits every uploaded file's buffered content into chunks
+// sized per opts, splitting text content on document-appropriate boundaries
+// (paragraph breaks for text/markdown, row groups for CSV) and falling back
+// to a plain byte-budget split when no such boundary is found. PDF content is
+// not split on page breaks: it is kept as a single chunk (see splitContent).
+func (e *Extractor) buildChunks(opts ChunkOptions) []chunk {
+	chunkSize := opts.ChunkTokens * approxCharsPerToken
+	overlap := opts.OverlapTokens * approxCharsPerToken
+
+	var chunks []chunk
+	for i, data := range e.fileData {
+		mime := e.files[i].MIMEType
+		for _, part := range splitContent(data, mime, chunkSize, overlap) {
+			chunks = append(chunks, chunk{data: part, mime: mime})
+		}
+	}
+	return chunks
+}
+
+// splitContent splits data into overlapping pieces of roughly chunkSize
+// bytes, preferring to cut on structural boundaries suited to mime. Binary
+// formats (e.g. raw PDF bytes, uploaded as-is by the passthrough converter)
+// have no text structure to cut on and would produce invalid documents if
+// sliced, so they are shipped whole regardless of chunkSize; only the PDF
+// converter currently falls in this bucket since this package has no PDF
+// text extraction yet.
+func splitContent(data []byte, mime string, chunkSize, overlap int) [][]byte {
+	if len(data) <= chunkSize || !isSplittableText(mime) {
+		return [][]byte{data}
+	}
+
+	var sep []byte
+	switch {
+	case strings.Contains(mime, "csv"):
+		sep = []byte("\n") // row break
+	default:
+		sep = []byte("\n\n") // paragraph break
+	}
+
+	units := bytes.Split(data, sep)
+	if len(units) <= 1 {
+		return splitFixed(data, chunkSize, overlap)
+	}
+
+	var pieces [][]byte
+	var cur [][]byte
+	curLen := 0
+	for _, u := range units {
+		if curLen > 0 && curLen+len(u)+len(sep) > chunkSize {
+			pieces = append(pieces, bytes.Join(cur, sep))
+			cur = overlapTail(cur, overlap, sep)
+			curLen = len(bytes.Join(cur, sep))
+		}
+		cur = append(cur, u)
+		curLen += len(u) + len(sep)
+	}
+	if len(cur) > 0 {
+		pieces = append(pieces, bytes.Join(cur, sep))
+	}
+	return pieces
+}
+
+// isSplittableText reports whether mime denotes text content that can be cut
+// on paragraph/row boundaries without corrupting it.
+func isSplittableText(mime string) bool {
+	return strings.HasPrefix(mime, "text/") || mime == "application/json"
+}
+
+// overlapTail returns the fewest trailing units of cur that together (with
+// their separators) reach at least overlap bytes, or all of cur if it is
+// shorter, to seed the next chunk with context.
+func overlapTail(cur [][]byte, overlap int, sep []byte) [][]byte {
+	if overlap <= 0 || len(cur) == 0 {
+		return nil
+	}
+	var tail [][]byte
+	total := 0
+	for i := len(cur) - 1; i >= 0; i-- {
+		total += len(cur[i]) + len(sep)
+		tail = append([][]byte{cur[i]}, tail...)
+		if total >= overlap {
+			break
+		}
+	}
+	return tail
+}
+
+// splitFixed splits data into fixed-size, overlapping byte windows. Used as
+// a fallback when no structural boundary is found.
+func splitFixed(data []byte, chunkSize, overlap int) [][]byte {
+	if chunkSize <= 0 {
+		return [][]byte{data}
+	}
+
+	var pieces [][]byte
+	for start := 0; start < len(data); start += max(chunkSize-overlap, 1) {
+		end := min(start+chunkSize, len(data))
+		pieces = append(pieces, data[start:end])
+		if end == len(data) {
+			break
+		}
+	}
+	return pieces
+}
+
+// mapChunks runs prompt against every chunk concurrently (bounded by
+// concurrency), each through a throwaway Extractor sharing this one's
+// client, model and settings, including retryPolicy and limiter so the
+// map phase's heavy parallel fan-out honors the same backoff/rate-limit
+// policy as a single Extract call.
+func (e *Extractor) mapChunks(schema *genai.Schema, prompt string, chunks []chunk, concurrency int) ([]string, error) {
+	results := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for i, c := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c chunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sub := &Extractor{
+				client:      e.client,
+				ctx:         e.ctx,
+				model:       e.model,
+				systInstr:   e.systInstr,
+				maxToken:    e.maxToken,
+				log:         e.log,
+				retryPolicy: e.retryPolicy,
+				limiter:     e.limiter,
+			}
+			defer sub.Close()
+
+			if err := sub.UploadBytes(fmt.Sprintf("chunk-%d", i), c.data, c.mime); err != nil {
+				errs[i] = err
+				return
+			}
+			text, err := sub.Extract(schema, prompt)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = text
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("map phase failed on chunk %d : %w", i, err)
+		}
+	}
+	return results, nil
+}
+
+// reduce asks the model to merge and deduplicate the map phase's partial
+// results into a single answer, re-prompting once if the merged result
+// doesn't conform to schema.
+func (e *Extractor) reduce(schema *genai.Schema, prompt string, partials []string) (string, error) {
+	reducePrompt := buildReducePrompt(prompt, partials, "")
+
+	config, contents := e.buildGenerateRequest(schema, reducePrompt, false)
+	r, err := e.generateWithRetry(contents, config)
+	if err != nil {
+		return "", err
+	}
+	merged := r.Text()
+
+	if schema == nil || validatesSchema(merged, schema) {
+		return merged, nil
+	}
+
+	// Re-prompt once, pointing out the conformance failure.
+	reducePrompt = buildReducePrompt(prompt, partials, "Your previous answer did not conform to the required JSON schema. Fix it.")
+	config, contents = e.buildGenerateRequest(schema, reducePrompt, false)
+	r, err = e.generateWithRetry(contents, config)
+	if err != nil {
+		return "", err
+	}
+	merged = r.Text()
+
+	if !validatesSchema(merged, schema) {
+		return "", fmt.Errorf("reduce phase : merged result still does not conform to schema after retry")
+	}
+	return merged, nil
+}
+
+// buildReducePrompt assembles the reduce-phase prompt from the original
+// prompt and every chunk's partial result.
+func buildReducePrompt(prompt string, partials []string, extra string) string {
+	var b strings.Builder
+	b.WriteString("The following are partial results obtained by running this instruction against successive, overlapping pieces of a larger document:\n")
+	b.WriteString(prompt)
+	b.WriteString("\n\nMerge and deduplicate them into a single, consistent answer to the original instruction.\n")
+	if extra != "" {
+		b.WriteString(extra)
+		b.WriteString("\n")
+	}
+	for i, p := range partials {
+		fmt.Fprintf(&b, "\n--- partial result %d ---\n%s\n", i+1, p)
+	}
+	return b.String()
+}
+
+// validatesSchema reports whether text parses as JSON whose top-level shape
+// (array vs. object) matches schema. This is a pragmatic conformance check,
+// not a full JSON-schema validation.
+func validatesSchema(text string, schema *genai.Schema) bool {
+	switch schema.Type {
+	case genai.TypeArray:
+		var v []json.RawMessage
+		return json.Unmarshal([]byte(text), &v) == nil
+	case genai.TypeObject:
+		var v map[string]json.RawMessage
+		return json.Unmarshal([]byte(text), &v) == nil
+	default:
+		return json.Valid([]byte(text))
+	}
+}
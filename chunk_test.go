@@ -0,0 +1,62 @@
+package myextract
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestExtractLargeBelowBudget(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	err = e.Upload(filepath.Join("testFiles", "txt.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := e.ExtractLarge(nil, "Résume ce fichier.", ChunkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+}
+
+func TestExtractLargeMapReduce(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	err = e.Upload(filepath.Join("testFiles", "pdf.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"summary": {Type: genai.TypeString},
+			},
+			PropertyOrdering: []string{"summary"},
+		}}
+
+	r, err := e.ExtractLarge(schema, "Liste les points clés de ce document.", ChunkOptions{
+		MaxInputTokens: 1,
+		ChunkTokens:    50,
+		OverlapTokens:  5,
+		Concurrency:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+}
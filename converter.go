@@ -0,0 +1,62 @@
+package myextract
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Converter turns a file on disk into an io.Reader ready for upload, along
+// with the MIME type the Gemini API should be told about. Implementations
+// are registered with RegisterConverter and are selected by Upload based on
+// the file's extension.
+type Converter interface {
+	// Extensions lists the file extensions this converter handles, including
+	// the leading dot and compared case-insensitively (e.g. ".docx").
+	Extensions() []string
+	// MIMEType is the MIME type reported to the API for the converted content.
+	MIMEType() string
+	// Convert reads path and returns its (possibly transformed) content.
+	// If the returned reader also implements io.Closer, Upload closes it
+	// once the upload completes.
+	Convert(path string) (io.Reader, error)
+}
+
+// converterRegistry maps an uppercased extension (with leading dot) to the
+// converter responsible for it. Registering a converter for an extension
+// that is already registered replaces the previous one, which is how
+// RegisterConverter lets callers override the built-in defaults.
+var (
+	converterMu       sync.RWMutex
+	converterRegistry = map[string]Converter{}
+)
+
+// RegisterConverter registers c for every extension it declares, overriding
+// any converter previously registered for the same extension. It is safe to
+// call concurrently and is typically used from an init() function to add
+// support for additional formats (XLSX, ODT, EPUB, images with OCR, ...).
+func RegisterConverter(c Converter) {
+	converterMu.Lock()
+	defer converterMu.Unlock()
+
+	for _, ext := range c.Extensions() {
+		converterRegistry[strings.ToUpper(ext)] = c
+	}
+}
+
+// lookupConverter returns the converter registered for ext (leading dot,
+// any case), if any.
+func lookupConverter(ext string) (Converter, bool) {
+	converterMu.RLock()
+	defer converterMu.RUnlock()
+
+	c, ok := converterRegistry[strings.ToUpper(ext)]
+	return c, ok
+}
+
+// errUnsupportedExtension builds the error Upload returns for an extension
+// with no registered converter.
+func errUnsupportedExtension(filePath string) error {
+	return fmt.Errorf("file type not supported : %v", filePath)
+}
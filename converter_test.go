@@ -0,0 +1,49 @@
+package myextract
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+type upperConverter struct{}
+
+func (upperConverter) Extensions() []string { return []string{".UPPER"} }
+func (upperConverter) MIMEType() string     { return "text/plain" }
+func (upperConverter) Convert(path string) (io.Reader, error) {
+	return bytes.NewReader([]byte("converted")), nil
+}
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(upperConverter{})
+
+	c, ok := lookupConverter(".upper")
+	if !ok {
+		t.Fatal("expected converter registered for .upper")
+	}
+	if c.MIMEType() != "text/plain" {
+		t.Fatalf("unexpected mime type : %q", c.MIMEType())
+	}
+}
+
+func TestUploadReaderAndBytes(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if err := e.UploadReader("inline.txt", bytes.NewReader([]byte("hello from a reader")), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.UploadBytes("inline-bytes.txt", []byte("hello from bytes"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := e.Extract(nil, "Que contiennent ces fichiers ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+}
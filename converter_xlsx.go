@@ -0,0 +1,48 @@
+package myextract
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// init registers the XLSX converter as a built-in, demonstrating how
+// RegisterConverter lets the package support formats beyond the original
+// hardcoded set.
+func init() {
+	RegisterConverter(xlsxConverter{})
+}
+
+// xlsxConverter flattens every sheet of an Excel workbook into CSV, since
+// the Gemini API has no native spreadsheet support. Sheets are concatenated
+// in workbook order, each preceded by a "# <sheet name>" marker line.
+type xlsxConverter struct{}
+
+func (xlsxConverter) Extensions() []string { return []string{".XLSX"} }
+func (xlsxConverter) MIMEType() string     { return "text/csv" }
+
+func (xlsxConverter) Convert(path string) (io.Reader, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&b, "# %s\n", sheet)
+		for _, row := range rows {
+			b.WriteString(strings.Join(row, ","))
+			b.WriteByte('\n')
+		}
+	}
+
+	return strings.NewReader(b.String()), nil
+}
@@ -0,0 +1,58 @@
+package myextract
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/xavier268/mydocx"
+)
+
+// init registers the converters that ship with the package: DOCX (text
+// extraction via mydocx) and a passthrough converter per already-supported
+// text-ish format.
+func init() {
+	RegisterConverter(docxConverter{})
+
+	RegisterConverter(passthroughConverter{exts: []string{".TXT"}, mime: "text/plain"})
+	RegisterConverter(passthroughConverter{exts: []string{".MD"}, mime: "text/md"})
+	RegisterConverter(passthroughConverter{exts: []string{".HTML", ".HTM"}, mime: "text/html"})
+	RegisterConverter(passthroughConverter{exts: []string{".CSV"}, mime: "text/csv"})
+	RegisterConverter(passthroughConverter{exts: []string{".XML"}, mime: "text/xml"})
+	RegisterConverter(passthroughConverter{exts: []string{".RTF"}, mime: "text/rtf"})
+	RegisterConverter(passthroughConverter{exts: []string{".PDF"}, mime: "application/pdf"})
+	// Note: .JASON handles common misspelling
+	RegisterConverter(passthroughConverter{exts: []string{".JSON", ".JASON"}, mime: "application/json"})
+}
+
+// docxConverter extracts the plain text content of a DOCX file before
+// upload, since the Gemini API has no native DOCX support.
+type docxConverter struct{}
+
+func (docxConverter) Extensions() []string { return []string{".DOCX"} }
+func (docxConverter) MIMEType() string     { return "text/plain" }
+
+func (docxConverter) Convert(path string) (io.Reader, error) {
+	data, err := mydocx.ExtractText(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cont := strings.Join(data["word/document.xml"], "\n")
+	return strings.NewReader(cont), nil
+}
+
+// passthroughConverter uploads a file's bytes unchanged, tagged with a fixed
+// MIME type. It backs every already-supported format that needs no
+// transformation (TXT, MD, HTML, CSV, XML, RTF, PDF, JSON).
+type passthroughConverter struct {
+	exts []string
+	mime string
+}
+
+func (p passthroughConverter) Extensions() []string { return p.exts }
+func (p passthroughConverter) MIMEType() string     { return p.mime }
+
+func (p passthroughConverter) Convert(path string) (io.Reader, error) {
+	return os.Open(path)
+}
@@ -3,28 +3,34 @@ package myextract
 // See : https://ai.google.dev/gemini-api/docs/structured-output?hl=fr#generating-enums
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"log"
-	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
-	"github.com/xavier268/mydocx"
 	"google.golang.org/genai"
 )
 
 // Extractor represents a document extraction client that interfaces with Google's Gemini API
 // to extract structured or unstructured data from various document formats.
 type Extractor struct {
-	client    *genai.Client   // Gemini API client for making requests
-	ctx       context.Context // Context for controlling request lifecycle
-	model     string          // Gemini model name to use for extraction
-	systInstr *genai.Content  // System instructions to guide the AI's behavior
-	maxToken  int32           // Maximum number of tokens to generate in response
-	files     []*genai.File   // Collection of uploaded files available for extraction
-	log       log.Logger      // Logger for tracking operations and debugging
+	client      *genai.Client   // Gemini API client for making requests
+	ctx         context.Context // Context for controlling request lifecycle
+	model       string          // Gemini model name to use for extraction
+	systInstr   *genai.Content  // System instructions to guide the AI's behavior
+	maxToken    int32           // Maximum number of tokens to generate in response
+	files       []*genai.File   // Collection of uploaded files available for extraction
+	fileData    [][]byte        // Raw content uploaded for each entry in files, same index, used for local chunking (see ExtractLarge)
+	cacheID     string          // Name of the active CachedContent, if any (see UseCache)
+	retryPolicy RetryPolicy     // Retry/backoff policy wrapping Extract, Upload and file deletion (see SetRetryPolicy)
+	limiter     *rateLimiter    // Optional rate limiter throttling API calls (see SetRateLimit)
+	log         log.Logger      // Logger for tracking operations and debugging
+	mu          sync.Mutex      // Guards files, fileData and cacheID against concurrent Upload calls (see UploadDir)
 }
 
 // NewExtractor creates a new Extractor instance with the provided API key and context.
@@ -78,11 +84,15 @@ func (e *Extractor) SetSystemPrompt(systInstr string) *Extractor {
 	return e
 }
 
-// Extract performs data extraction from all uploaded files using the provided prompt.
-// If a schema is provided, the response will be structured JSON conforming to that schema.
-// If schema is nil, the response will be plain text.
-// All files previously uploaded to this extractor are included in the extraction context.
-func (e *Extractor) Extract(schema *genai.Schema, prompt string) (result string, err error) {
+// buildGenerateRequest assembles the GenerateContentConfig and Content slice
+// shared by Extract and its streaming variants, applying the extractor's
+// token limit, system instructions and active cache (if any).
+// attachContext selects whether the uploaded files / active cache are
+// attached at all: ExtractLarge's reduce phase passes false, since its
+// prompt already inlines every chunk's result and re-attaching the cached
+// or uploaded corpus would be redundant (and, for an active cache, would
+// pointlessly re-send the whole original document set).
+func (e *Extractor) buildGenerateRequest(schema *genai.Schema, prompt string, attachContext bool) (*genai.GenerateContentConfig, []*genai.Content) {
 	var config *genai.GenerateContentConfig
 
 	// Configure response format based on whether schema is provided
@@ -105,32 +115,68 @@ func (e *Extractor) Extract(schema *genai.Schema, prompt string) (result string,
 		config.MaxOutputTokens = e.maxToken
 	}
 
-	// Apply system instructions if set
-	if e.systInstr != nil {
-		config.SystemInstruction = e.systInstr
+	if !attachContext {
+		if e.systInstr != nil {
+			config.SystemInstruction = e.systInstr
+		}
+		contents := []*genai.Content{
+			genai.NewContentFromParts([]*genai.Part{genai.NewPartFromText(prompt)}, genai.RoleUser),
+		}
+		return config, contents
 	}
 
-	// Build prompt parts including all uploaded files and the text prompt
-	// Select the uploaded files
-	promptParts := make([]*genai.Part, 0, len(e.files)+1)
+	e.mu.Lock()
+	cacheID := e.cacheID
+	files := append([]*genai.File(nil), e.files...)
+	e.mu.Unlock()
 
-	// Add each uploaded file as a URI part
-	for _, f := range e.files {
-		promptParts = append(promptParts, genai.NewPartFromURI(f.URI, f.MIMEType))
-	}
+	// Build prompt parts. When a cache is active, the cached content already
+	// carries both the uploaded files and the system prompt (see
+	// CreateCache); the Gemini API rejects a request that sets both
+	// SystemInstruction and CachedContent, so neither is re-attached here.
+	var promptParts []*genai.Part
+
+	if cacheID != "" {
+		config.CachedContent = cacheID
+		promptParts = []*genai.Part{genai.NewPartFromText(prompt)}
+	} else {
+		// Apply system instructions if set
+		if e.systInstr != nil {
+			config.SystemInstruction = e.systInstr
+		}
+
+		// Select the uploaded files
+		promptParts = make([]*genai.Part, 0, len(files)+1)
+
+		// Add each uploaded file as a URI part
+		for _, f := range files {
+			promptParts = append(promptParts, genai.NewPartFromURI(f.URI, f.MIMEType))
+		}
 
-	// Add the text prompt as the final part
-	// Add the prompt, create the content input
-	promptParts = append(promptParts, genai.NewPartFromText(prompt))
+		// Add the text prompt as the final part
+		// Add the prompt, create the content input
+		promptParts = append(promptParts, genai.NewPartFromText(prompt))
+	}
 
 	// Create content structure for the API call
 	contents := []*genai.Content{
 		genai.NewContentFromParts(promptParts, genai.RoleUser),
 	}
 
-	// Make the API call to generate content
+	return config, contents
+}
+
+// Extract performs data extraction from all uploaded files using the provided prompt.
+// If a schema is provided, the response will be structured JSON conforming to that schema.
+// If schema is nil, the response will be plain text.
+// All files previously uploaded to this extractor are included in the extraction context.
+func (e *Extractor) Extract(schema *genai.Schema, prompt string) (result string, err error) {
+	config, contents := e.buildGenerateRequest(schema, prompt, true)
+
+	// Make the API call to generate content, retrying per the configured
+	// RetryPolicy on transient failures.
 	// query
-	r, err := e.client.Models.GenerateContent(e.ctx, e.model, contents, config)
+	r, err := e.generateWithRetry(contents, config)
 	if err != nil {
 		return "", err
 	}
@@ -139,6 +185,18 @@ func (e *Extractor) Extract(schema *genai.Schema, prompt string) (result string,
 	return r.Text(), nil
 }
 
+// generateWithRetry is e.client.Models.GenerateContent wrapped in e.withRetry,
+// shared by Extract and ExtractLarge's reduce phase.
+func (e *Extractor) generateWithRetry(contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	var r *genai.GenerateContentResponse
+	err := e.withRetry(func() error {
+		var callErr error
+		r, callErr = e.client.Models.GenerateContent(e.ctx, e.model, contents, config)
+		return callErr
+	})
+	return r, err
+}
+
 // Close cleans up all resources associated with the extractor.
 // This includes deleting all uploaded files from the Gemini API to prevent ongoing storage costs.
 // This method is idempotent and can be called multiple times safely.
@@ -146,8 +204,22 @@ func (e *Extractor) Extract(schema *genai.Schema, prompt string) (result string,
 func (e *Extractor) Close() error {
 	var ee []string // collect errors !
 
+	e.mu.Lock()
+	cacheID := e.cacheID
+	files := e.files
+	e.files = nil    // reset files
+	e.fileData = nil // release buffered content
+	e.mu.Unlock()
+
+	// Tear down the active cache alongside the files it was built from
+	if cacheID != "" {
+		if err := e.DeleteCache(cacheID); err != nil {
+			ee = append(ee, err.Error())
+		}
+	}
+
 	// Iterate through all uploaded files and delete them from the API
-	for _, f := range e.files {
+	for _, f := range files {
 		if f == nil {
 			continue
 		}
@@ -158,29 +230,28 @@ func (e *Extractor) Close() error {
 		// Delete file using background context to ensure deletion completes
 		// even if the extractor's context is cancelled
 		// don't use existing context, to ensure deletion ...
-		_, err := e.client.Files.Delete(context.Background(), f.Name, nil)
+		err := e.withRetryCtx(context.Background(), func() error {
+			_, callErr := e.client.Files.Delete(context.Background(), f.Name, nil)
+			return callErr
+		})
 		if err != nil {
 			// Collect errors but continue deleting other files
 			ee = append(ee, err.Error())
 		}
 	}
 
-	// Clear the files slice to prevent double-deletion
-	e.files = nil // reset files
-
 	// Return any errors encountered during deletion
 	if len(ee) == 0 {
 		return nil
-	} else {
-		ee = nil
-		return fmt.Errorf("error while deleting files : %v", ee)
 	}
+	return fmt.Errorf("error while deleting files : %s", strings.Join(ee, "; "))
 }
 
 // Upload adds a file from the local filesystem to the extractor for use in subsequent extractions.
-// The file type is automatically detected by extension and appropriate MIME type is set.
-// Special handling for DOCX files: text is extracted before upload.
-// Supported formats: DOCX, TXT, MD, HTML, HTM, CSV, XML, RTF, PDF, JSON
+// The file type is automatically detected by extension and dispatched to the
+// Converter registered for it (see RegisterConverter); DOCX is converted to
+// plain text, other registered formats are handled per their own Converter.
+// Built-in formats: DOCX, TXT, MD, HTML, HTM, CSV, XML, RTF, PDF, JSON, XLSX.
 func (e *Extractor) Upload(filePath string) error {
 	// Convert relative path to absolute path for consistency
 	// Convert to absolute path
@@ -189,81 +260,77 @@ func (e *Extractor) Upload(filePath string) error {
 		return err
 	}
 
-	// Initialize upload configuration
-	// detect mime type
-	ufc := &genai.UploadFileConfig{}
-
 	// Extract file extension and convert to uppercase for comparison
 	ext := strings.ToUpper(filepath.Ext(filePath))
 
-	// Special handling for DOCX files - extract text content first
-	// Handle docx by extracting text first
-	if ext == ".DOCX" {
-		// Set MIME type for extracted text
-		ufc.MIMEType = "text/plain"
+	conv, ok := lookupConverter(ext)
+	if !ok {
+		return errUnsupportedExtension(filePath)
+	}
 
-		// Extract text from DOCX using mydocx library
-		data, err := mydocx.ExtractText(filePath)
-		if err != nil {
-			return err
-		}
+	r, err := conv.Convert(filePath)
+	if err != nil {
+		return err
+	}
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
 
-		// Join extracted text lines into single content string
-		cont := strings.Join(data["word/document.xml"], "\n")
+	return e.uploadReader(filePath, r, conv.MIMEType())
+}
 
-		// Upload the extracted text content
-		f, err := e.client.Files.Upload(e.ctx, strings.NewReader(cont), ufc)
-		if err != nil {
-			return err
-		}
+// UploadReader uploads the content read from r, tagged with mime, without
+// touching the local filesystem. name is used only for logging; the Gemini
+// API assigns its own identifier to the uploaded file.
+func (e *Extractor) UploadReader(name string, r io.Reader, mime string) error {
+	return e.uploadReader(name, r, mime)
+}
 
-		// Add uploaded file to the collection and log success
-		e.files = append(e.files, f)
-		e.log.Printf("Uploaded (%s) : %q\n", f.Name, filePath)
-		return nil
+// UploadBytes uploads data already held in memory, tagged with mime. It is a
+// convenience wrapper around UploadReader for callers that already have the
+// file's content as a byte slice.
+func (e *Extractor) UploadBytes(name string, data []byte, mime string) error {
+	return e.uploadReader(name, bytes.NewReader(data), mime)
+}
+
+// uploadReader performs the actual API upload shared by Upload, UploadReader
+// and UploadBytes: it invalidates any active cache (the newly uploaded
+// content isn't part of it), sends r to the API and records the result.
+func (e *Extractor) uploadReader(label string, r io.Reader, mime string) error {
+	// A cache built from a previous set of files no longer reflects the
+	// content about to be uploaded, so it must not be reused silently.
+	e.mu.Lock()
+	cacheID := e.cacheID
+	e.mu.Unlock()
+
+	if cacheID != "" {
+		if err := e.DeleteCache(cacheID); err != nil {
+			return fmt.Errorf("cannot invalidate active cache before upload : %w", err)
+		}
 	}
 
-	// For non-DOCX files, open the file for direct upload
-	// now, we need to open file
-	of, err := os.Open(filePath)
+	// Buffer the content locally before sending it: this lets ExtractLarge
+	// later re-chunk it without re-reading the original source.
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
-	defer of.Close()
-
-	// Set appropriate MIME type based on file extension
-	// set mime type for recognized file types
-	switch ext {
-	case ".TXT":
-		ufc.MIMEType = "text/plain"
-	case ".MD":
-		ufc.MIMEType = "text/md"
-	case ".HTML", ".HTM":
-		ufc.MIMEType = "text/html"
-	case ".CSV":
-		ufc.MIMEType = "text/csv"
-	case ".XML":
-		ufc.MIMEType = "text/xml"
-	case ".RTF":
-		ufc.MIMEType = "text/rtf"
-	case ".PDF":
-		ufc.MIMEType = "application/pdf"
-	case ".JSON", ".JASON": // Note: .JASON handles common misspelling
-		ufc.MIMEType = "application/json"
-	default:
-		// Return error for unsupported file types
-		return fmt.Errorf("file type not supported : %v", filePath)
-	}
 
-	// Upload the file directly to the API
-	// actual upload for non word files
-	f, err := e.client.Files.Upload(e.ctx, of, ufc)
+	var f *genai.File
+	err = e.withRetry(func() error {
+		var callErr error
+		f, callErr = e.client.Files.Upload(e.ctx, bytes.NewReader(data), &genai.UploadFileConfig{MIMEType: mime})
+		return callErr
+	})
 	if err != nil {
 		return err
 	}
 
 	// Add uploaded file to the collection and log success
+	e.mu.Lock()
 	e.files = append(e.files, f)
-	e.log.Printf("Uploaded (%s) : %q\n", f.Name, filePath)
+	e.fileData = append(e.fileData, data)
+	e.mu.Unlock()
+	e.log.Printf("Uploaded (%s) : %q\n", f.Name, label)
 	return nil
 }
@@ -0,0 +1,248 @@
+package myextract
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// RetryPolicy controls how Extract, Upload and file deletion cope with
+// transient API failures (rate limiting, server errors, deadlines).
+type RetryPolicy struct {
+	MaxAttempts     int                  // Total number of attempts, including the first. <= 1 disables retries.
+	InitialBackoff  time.Duration        // Delay before the first retry.
+	MaxBackoff      time.Duration        // Upper bound the backoff is capped at as it grows.
+	Jitter          float64              // Fraction (0..1) of each backoff randomized, to avoid retry storms.
+	RetryableErrors func(err error) bool // Classifies err as worth retrying. nil uses defaultRetryableError (429/5xx/context deadline, not permanent 4xx).
+}
+
+// defaultRetryPolicy is applied until SetRetryPolicy is called; it disables
+// retries, preserving the pre-existing fail-fast behaviour.
+var defaultRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// SetRetryPolicy installs p as the retry policy wrapping Extract, Upload and
+// file deletion. Returns the extractor for method chaining.
+func (e *Extractor) SetRetryPolicy(p RetryPolicy) *Extractor {
+	if p.RetryableErrors == nil {
+		p.RetryableErrors = defaultRetryableError
+	}
+	e.retryPolicy = p
+	return e
+}
+
+// defaultRetryableError retries rate limiting, server errors and context
+// deadlines, but treats other 4xx errors (bad request, auth, not found, ...)
+// as permanent.
+func defaultRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests:
+			return true
+		case apiErr.Code >= 500 && apiErr.Code < 600:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Fall back to sniffing the error text for API clients that don't
+	// surface a typed *googleapi.Error.
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "429") ||
+		strings.Contains(msg, "rate limit") ||
+		strings.Contains(msg, "too many requests") ||
+		strings.Contains(msg, "deadline exceeded") ||
+		strings.Contains(msg, "unavailable")
+}
+
+// retryAfter extracts the server-requested backoff from err's Retry-After
+// header, if any.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0, false
+	}
+
+	v := apiErr.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, convErr := strconv.Atoi(v); convErr == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, convErr := http.ParseTime(v); convErr == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
+
+// withRetry runs op, retrying it per e.retryPolicy while honoring e.ctx for
+// cancellation. Rate limiting (see SetRateLimit) is applied before every
+// attempt, including the first.
+func (e *Extractor) withRetry(op func() error) error {
+	return e.withRetryCtx(e.ctx, op)
+}
+
+// withRetryCtx is withRetry, but waits on the rate limiter and on retry
+// delays using the given ctx instead of e.ctx. Close uses this with
+// context.Background() so that file deletion keeps retrying even after the
+// extractor's own context has been cancelled or has expired.
+func (e *Extractor) withRetryCtx(ctx context.Context, op func() error) error {
+	p := e.retryPolicy
+	if p.MaxAttempts <= 0 {
+		p = defaultRetryPolicy
+	}
+	retryable := p.RetryableErrors
+	if retryable == nil {
+		retryable = defaultRetryableError
+	}
+
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if e.limiter != nil {
+			if waitErr := e.limiter.Wait(ctx); waitErr != nil {
+				return waitErr
+			}
+		}
+
+		err = op()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := backoff
+		if wait, ok := retryAfter(err); ok {
+			delay = wait
+		}
+		if delay < 0 {
+			delay = 0
+		}
+		if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+			delay = p.MaxBackoff
+		}
+		delay = applyJitter(delay, p.Jitter)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if backoff > 0 {
+			backoff *= 2
+			if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+				backoff = p.MaxBackoff
+			}
+		}
+	}
+}
+
+// applyJitter randomizes delay by up to jitter (a 0..1 fraction), never
+// returning a negative duration.
+func applyJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	spread := float64(delay) * jitter
+	return delay - time.Duration(spread/2) + time.Duration(rand.Float64()*spread)
+}
+
+// rateLimiter is a simple token-bucket limiter: tokens refill continuously
+// at rps and up to burst may be spent at once.
+type rateLimiter struct {
+	mu         sync.Mutex
+	rps        float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rps:        rps,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		d := l.reserve()
+		if d <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(d):
+			continue
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve refills the bucket, consumes a token if one is available and
+// returns 0, or returns how long to wait before trying again.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	l.lastRefill = now
+	l.tokens = min(l.tokens+elapsed*l.rps, l.burst)
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	missing := 1 - l.tokens
+	return time.Duration(missing / l.rps * float64(time.Second))
+}
+
+// SetRateLimit caps how many API calls (Extract, Upload, file deletion) the
+// extractor issues, via a token bucket refilling at rps requests per second
+// with room for up to burst requests at once. A zero rps disables rate
+// limiting. Returns the extractor for method chaining.
+func (e *Extractor) SetRateLimit(rps float64, burst int) *Extractor {
+	if rps <= 0 {
+		e.limiter = nil
+		return e
+	}
+	e.limiter = newRateLimiter(rps, burst)
+	return e
+}
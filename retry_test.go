@@ -0,0 +1,41 @@
+package myextract
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExtractorRetryAndRateLimit(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	e.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Jitter:         0.2,
+	})
+	e.SetRateLimit(2, 1)
+
+	r, err := e.Extract(nil, "Dis bonjour.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+}
+
+func TestDefaultRetryableError(t *testing.T) {
+	if defaultRetryableError(nil) {
+		t.Fatal("nil error should not be retryable")
+	}
+	if !defaultRetryableError(context.DeadlineExceeded) {
+		t.Fatal("context.DeadlineExceeded should be retryable")
+	}
+	if defaultRetryableError(context.Canceled) {
+		t.Fatal("context.Canceled should not be retryable")
+	}
+}
@@ -0,0 +1,118 @@
+package myextract
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// UsageMetadata reports the token counts the API billed for a single
+// streamed Extract call.
+type UsageMetadata struct {
+	PromptTokenCount   int32 // Tokens consumed by the prompt and uploaded files
+	ResponseTokenCount int32 // Tokens produced by the model
+	TotalTokenCount    int32 // Sum of prompt and response tokens
+}
+
+// ExtractStream behaves like Extract but streams the response as it is
+// generated: onChunk is invoked with each partial text fragment as soon as it
+// arrives, letting callers show progressive output instead of waiting for the
+// whole response. It returns the fully assembled text together with the
+// usage metadata reported by the API. onChunk may be nil if only the final
+// text and usage are needed.
+// Streaming can be aborted by cancelling the Extractor's context.
+func (e *Extractor) ExtractStream(schema *genai.Schema, prompt string, onChunk func(text string)) (finalText string, usage *UsageMetadata, err error) {
+	config, contents := e.buildGenerateRequest(schema, prompt, true)
+
+	var b strings.Builder
+	var lastUsage *genai.GenerateContentResponseUsageMetadata
+
+	for resp, err := range e.client.Models.GenerateContentStream(e.ctx, e.model, contents, config) {
+		if err != nil {
+			return "", nil, err
+		}
+
+		chunk := resp.Text()
+		if chunk != "" {
+			b.WriteString(chunk)
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+		}
+
+		if resp.UsageMetadata != nil {
+			lastUsage = resp.UsageMetadata
+		}
+	}
+
+	if lastUsage != nil {
+		usage = &UsageMetadata{
+			PromptTokenCount:   lastUsage.PromptTokenCount,
+			ResponseTokenCount: lastUsage.CandidatesTokenCount,
+			TotalTokenCount:    lastUsage.TotalTokenCount,
+		}
+	}
+
+	return b.String(), usage, nil
+}
+
+// ExtractStreamJSON streams a schema-driven extraction and incrementally
+// parses the response, which is expected to be a top-level JSON array.
+// Each array element is decoded and passed to onElement as soon as it has
+// fully arrived, via a streaming json.Decoder fed directly from the
+// response chunks, rather than waiting for the whole array to close.
+// It returns the full raw JSON response together with the usage metadata.
+func (e *Extractor) ExtractStreamJSON(schema *genai.Schema, prompt string, onElement func(raw json.RawMessage)) (finalText string, usage *UsageMetadata, err error) {
+	pr, pw := io.Pipe()
+	decodeErr := make(chan error, 1)
+
+	go func() {
+		defer close(decodeErr)
+		dec := json.NewDecoder(pr)
+
+		// Consume the opening '[' of the top-level array.
+		if _, tokErr := dec.Token(); tokErr != nil {
+			if errors.Is(tokErr, io.EOF) {
+				io.Copy(io.Discard, pr)
+				return
+			}
+			pr.CloseWithError(tokErr)
+			decodeErr <- tokErr
+			return
+		}
+
+		for dec.More() {
+			var raw json.RawMessage
+			if decErr := dec.Decode(&raw); decErr != nil {
+				pr.CloseWithError(decErr)
+				decodeErr <- decErr
+				return
+			}
+			if onElement != nil {
+				onElement(raw)
+			}
+		}
+
+		// Drain anything left (the closing ']' token) so the writer side
+		// never blocks on a full pipe buffer once decoding is done.
+		io.Copy(io.Discard, pr)
+	}()
+
+	finalText, usage, err = e.ExtractStream(schema, prompt, func(text string) {
+		pw.Write([]byte(text)) // best-effort : a closed reader just drops further chunks
+	})
+	if err != nil {
+		pw.CloseWithError(err)
+		<-decodeErr
+		return "", nil, err
+	}
+	pw.Close()
+
+	if decErr := <-decodeErr; decErr != nil {
+		return finalText, usage, decErr
+	}
+	return finalText, usage, nil
+}
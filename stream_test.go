@@ -0,0 +1,61 @@
+package myextract
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestExtractorStream(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	var chunks int
+	text, usage, err := e.ExtractStream(nil, "Raconte moi une courte histoire.", func(text string) {
+		chunks++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks == 0 {
+		t.Fatal("expected at least one streamed chunk")
+	}
+	if usage == nil || usage.TotalTokenCount == 0 {
+		t.Fatal("expected non-zero usage metadata")
+	}
+	t.Log(text)
+}
+
+func TestExtractorStreamJSON(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	schema := &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"recipeName": {Type: genai.TypeString},
+			},
+			PropertyOrdering: []string{"recipeName"},
+		}}
+
+	var elements []json.RawMessage
+	_, _, err = e.ExtractStreamJSON(schema, "Donne moi 3 recettes de cuisine.", func(raw json.RawMessage) {
+		elements = append(elements, raw)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elements) == 0 {
+		t.Fatal("expected at least one decoded array element")
+	}
+}
@@ -0,0 +1,185 @@
+package myextract
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// UploadDirOptions configures UploadDir's tree walk.
+type UploadDirOptions struct {
+	Include        []string // Glob patterns (matched against the path relative to root, or against the file's base name) a file must satisfy to be uploaded. Empty means "all files".
+	Exclude        []string // Glob patterns excluding otherwise-matched files. Checked after Include.
+	MaxFileSize    int64    // Skip files larger than this, in bytes. 0 means unlimited.
+	MaxFiles       int      // Stop the walk once this many files have been selected for upload; files whose upload then fails still count. 0 means unlimited.
+	FollowSymlinks bool     // Upload symlinked files encountered during the walk. Symlinked directories are never descended into (filepath.WalkDir doesn't follow them), regardless of this flag.
+	Concurrency    int      // Number of files uploaded in parallel. Values <= 0 default to 4.
+}
+
+// UploadDirError aggregates the per-file failures encountered by UploadDir.
+// A non-empty UploadDirError does not mean every file failed: files not
+// listed here were uploaded successfully.
+type UploadDirError struct {
+	Failures map[string]error // File path (relative to root) to the error it produced
+}
+
+func (e *UploadDirError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d file(s) failed to upload", len(e.Failures))
+	for path, err := range e.Failures {
+		fmt.Fprintf(&b, "\n  %s: %v", path, err)
+	}
+	return b.String()
+}
+
+// UploadDir walks root and uploads every file matching opts' include/exclude
+// patterns through the converter registry (see RegisterConverter), bounding
+// concurrency to opts.Concurrency workers. It honors the Extractor's context
+// for cancellation. Per-file failures (unsupported extension, read error,
+// API error, ...) are collected and returned together as a *UploadDirError
+// once the whole tree has been processed; they do not abort the batch.
+func (e *Extractor) UploadDir(root string, opts UploadDirOptions) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	paths, err := collectUploadDirPaths(root, opts)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures = map[string]error{}
+		sem      = make(chan struct{}, concurrency)
+	)
+
+	for _, p := range paths {
+		select {
+		case <-e.ctx.Done():
+			rel, relErr := filepath.Rel(root, p)
+			if relErr != nil {
+				rel = p
+			}
+			mu.Lock()
+			failures[rel] = e.ctx.Err()
+			mu.Unlock()
+			continue
+		default:
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := e.Upload(path); err != nil {
+				mu.Lock()
+				rel, relErr := filepath.Rel(root, path)
+				if relErr != nil {
+					rel = path
+				}
+				failures[rel] = err
+				mu.Unlock()
+			}
+		}(p)
+	}
+
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return nil
+	}
+	return &UploadDirError{Failures: failures}
+}
+
+// collectUploadDirPaths walks root and returns the paths (root joined with
+// each file's path below it) of the files to upload, after applying opts'
+// filters. It performs no
+// network I/O.
+func collectUploadDirPaths(root string, opts UploadDirOptions) ([]string, error) {
+	var paths []string
+
+	walkFn := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				return nil
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil // broken symlink, skip
+			}
+			if info.IsDir() {
+				return nil
+			}
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+
+		if !matchesAny(opts.Include, rel, true) {
+			return nil
+		}
+		if matchesAny(opts.Exclude, rel, false) {
+			return nil
+		}
+
+		if opts.MaxFileSize > 0 {
+			info, err := os.Stat(path) // follows symlinks, so a linked file's own size is checked
+			if err != nil {
+				return nil
+			}
+			if info.Size() > opts.MaxFileSize {
+				return nil
+			}
+		}
+
+		if opts.MaxFiles > 0 && len(paths) >= opts.MaxFiles {
+			return fs.SkipAll
+		}
+
+		paths = append(paths, path)
+		return nil
+	}
+
+	if err := filepath.WalkDir(root, walkFn); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// matchesAny reports whether rel matches one of patterns. When patterns is
+// empty, it returns defaultForEmpty (true for Include, so "no include
+// patterns" means "include everything"; false for Exclude, so "no exclude
+// patterns" means "exclude nothing").
+func matchesAny(patterns []string, rel string, defaultForEmpty bool) bool {
+	if len(patterns) == 0 {
+		return defaultForEmpty
+	}
+
+	for _, pat := range patterns {
+		if ok, _ := filepath.Match(pat, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pat, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package myextract
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUploadDir(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	err = e.UploadDir("testFiles", UploadDirOptions{
+		Include:     []string{"*.pdf", "*.docx", "*.csv"},
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := e.Extract(nil, "Résume ces fichiers en 3 lignes chacun.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(r)
+}
+
+func TestUploadDirReportsPerFileFailures(t *testing.T) {
+	e, err := NewExtractor(context.Background(), TEST_KEY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	err = e.UploadDir("testFiles", UploadDirOptions{})
+	if err == nil {
+		t.Fatal("expected an error from an unsupported file in testFiles")
+	}
+	if _, ok := err.(*UploadDirError); !ok {
+		t.Fatalf("expected *UploadDirError, got %T", err)
+	}
+}